@@ -3,12 +3,13 @@ package gpg
 import (
 	"bytes"
 	"context"
-	"io/ioutil"
 	"os"
+	"sync"
 
 	"github.com/pkg/errors"
 	"golang.org/x/crypto/openpgp"
 	"golang.org/x/crypto/openpgp/armor"
+	"golang.org/x/crypto/openpgp/clearsign"
 	"golang.org/x/crypto/openpgp/packet"
 )
 
@@ -19,123 +20,175 @@ type GPG struct {
 	PublicKeyPath  string
 	PrivateKeyPath string
 	Passphrase     string
+	// KeyringPath, if set, points at a multi-entity keyring file used by
+	// GenerateKey, ImportKey, and ListKeys.
+	KeyringPath string
+
+	// PublicKeyPaths and KeyIDs name additional recipients for Encrypt,
+	// alongside PublicKeyPath/KeyID which remain supported as single-key
+	// convenience aliases. A single ciphertext is produced that any of the
+	// recipients can decrypt.
+	PublicKeyPaths []string
+	KeyIDs         []string
+	// HiddenRecipients lists recipients (by key ID or email, matching an
+	// entry in PublicKeyPaths/KeyIDs) whose key ID is omitted from the
+	// ciphertext (RFC 4880 §5.1 wildcard recipient), so metadata analysis
+	// cannot enumerate who can decrypt the message.
+	HiddenRecipients []string
+
+	sources         []KeySource
+	privateKeyCache map[string]*openpgp.Entity
+	cacheMu         sync.Mutex
 }
 
-// New creates GPG provider
-func New(publicKeyPath, privateKeyPath, passphrase, keyID, keyServer string) (*GPG, error) {
+// New creates a GPG provider backed by the given key sources, tried in
+// order until one of them can provide the requested key.
+func New(sources []KeySource, passphrase, keyID, keyServer string) (*GPG, error) {
 	return &GPG{
-		PublicKeyPath:  publicKeyPath,
-		PrivateKeyPath: privateKeyPath,
-		Passphrase:     passphrase,
-		KeyID:          keyID,
-		KeyServer:      keyServer,
+		Passphrase:      passphrase,
+		KeyID:           keyID,
+		KeyServer:       keyServer,
+		sources:         sources,
+		privateKeyCache: make(map[string]*openpgp.Entity),
 	}, nil
 }
 
+// NewFileGPG creates a GPG provider reading keys from local files, with an
+// optional keyserver fallback for public keys. It calls New with a
+// FileKeySource and, when keyServer is set, a KeyserverSource, and also sets
+// PublicKeyPath and PrivateKeyPath on the result.
+func NewFileGPG(publicKeyPath, privateKeyPath, passphrase, keyID, keyServer string) (*GPG, error) {
+	sources := []KeySource{&FileKeySource{PublicKeyPath: publicKeyPath, PrivateKeyPath: privateKeyPath}}
+	if len(keyServer) > 0 {
+		sources = append(sources, &KeyserverSource{KeyServer: keyServer})
+	}
+	gpg, err := New(sources, passphrase, keyID, keyServer)
+	if err != nil {
+		return nil, err
+	}
+	gpg.PublicKeyPath = publicKeyPath
+	gpg.PrivateKeyPath = privateKeyPath
+	return gpg, nil
+}
+
 // Encrypt is responsible for encrypting plaintext and returning ciphertext in bytes using GPG (GnuPG).
-// It supports local and remote keys.
+// It supports local and remote keys, and any number of recipients via
+// PublicKeyPaths/KeyIDs so that a single ciphertext can be decrypted by any
+// of them. If a private key and passphrase are configured, the plaintext is
+// signed before it is encrypted.
 // See Crypt.Encrypt
 func (p *GPG) Encrypt(plaintext []byte) ([]byte, error) {
-	if len(p.PublicKeyPath) > 0 {
-		return p.encryptWithKey(plaintext)
-	} else if len(p.KeyID) > 0 && len(p.KeyServer) > 0 {
-		return p.encryptWithKeyServer(plaintext)
+	if len(p.PrivateKeyPath) > 0 && len(p.Passphrase) > 0 {
+		signed, err := p.SignClearsign(plaintext)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to sign before encrypting")
+		}
+		plaintext = signed
+	}
+
+	buf := new(bytes.Buffer)
+	if err := p.EncryptStream(buf, bytes.NewReader(plaintext), nil); err != nil {
+		return nil, err
 	}
-	return nil, errors.New("UNSUPPORTED OPERATION")
+	return buf.Bytes(), nil
 }
 
 // Decrypt is responsible for decrypting ciphertext and returning plaintext in bytes using GPG (GnuPG).
 // See Crypt.Decrypt
 func (p *GPG) Decrypt(ciphertext []byte) ([]byte, error) {
-	return p.decryptWithKey(ciphertext)
-}
-
-func (p *GPG) encryptWithKeyServer(plaintext []byte) ([]byte, error) {
-	keyServer, err := ParseKeyserver(p.KeyServer)
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to parse keyserver")
-	}
-	keyID, err := ParseKeyID(p.KeyID)
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to parse key")
-	}
-	client, err := NewClient(keyServer, nil)
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to create keyserver client")
-	}
-	entities, err := client.GetKeysByID(context.TODO(), keyID)
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to get key")
-	}
-	if len(entities) != 1 {
-		return nil, errors.Wrap(err, "more than one entry for the key")
+	buf := new(bytes.Buffer)
+	if _, err := p.DecryptStream(buf, bytes.NewReader(ciphertext)); err != nil {
+		return nil, err
 	}
-	return p.encrypt(plaintext, entities)
+	return buf.Bytes(), nil
 }
 
-func (p *GPG) encryptWithKey(plaintext []byte) ([]byte, error) {
-	entity, err := readEntity(p.PublicKeyPath)
+// DecryptVerify decrypts ciphertext like Decrypt, and additionally verifies
+// a clearsigned payload produced by a sign-then-encrypt Encrypt call,
+// returning the verified signer.
+func (p *GPG) DecryptVerify(ciphertext []byte) ([]byte, *Signer, error) {
+	plaintext, err := p.Decrypt(ciphertext)
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to read public key")
+		return nil, nil, err
 	}
 
-	return p.encrypt(plaintext, openpgp.EntityList{entity})
-}
-
-func (p *GPG) encrypt(plaintext []byte, entities []*openpgp.Entity) ([]byte, error) {
-	buf := new(bytes.Buffer)
-	writer, err := openpgp.Encrypt(buf, entities, nil, nil, nil)
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to encrypt")
+	block, _ := clearsign.Decode(plaintext)
+	if block == nil {
+		return plaintext, nil, nil
 	}
-	_, err = writer.Write(plaintext)
+
+	keyring, err := p.verificationKeyring()
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to write ciphertext")
+		return nil, nil, errors.Wrap(err, "failed to load verification keys")
 	}
-	err = writer.Close()
+	signer, err := verifySignature(keyring, block.ArmoredSignature.Body, bytes.NewReader(block.Bytes))
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
+	return block.Bytes, signer, nil
+}
 
-	encrypted, err := ioutil.ReadAll(buf)
-	if err != nil {
-		return nil, err
+// loadPublicEntity fetches a public key by ID from the configured key
+// sources, trying each in order.
+func (p *GPG) loadPublicEntity(ctx context.Context, keyID string) (*openpgp.Entity, error) {
+	var lastErr error
+	for _, source := range p.sources {
+		entity, err := source.FetchPublic(ctx, keyID)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		return entity, nil
 	}
-
-	return encrypted, nil
+	return nil, errors.Wrap(lastErr, "no key source could provide the public key")
 }
 
-func (p *GPG) decryptWithKey(ciphertext []byte) ([]byte, error) {
-	privateKeyEntity, err := readEntity(p.PrivateKeyPath)
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to read private key")
+// loadPrivateEntity fetches a private key by ID from the configured key
+// sources, decrypts it with the configured passphrase, and caches the
+// decrypted entity so subsequent calls don't re-prompt for the passphrase.
+func (p *GPG) loadPrivateEntity(ctx context.Context, keyID string) (*openpgp.Entity, error) {
+	p.cacheMu.Lock()
+	cached, ok := p.privateKeyCache[keyID]
+	p.cacheMu.Unlock()
+	if ok {
+		return cached, nil
 	}
 
-	if privateKeyEntity.PrivateKey.Encrypted {
-		passphraseBytes := []byte(p.Passphrase)
-		err = privateKeyEntity.PrivateKey.Decrypt(passphraseBytes)
+	var lastErr error
+	for _, source := range p.sources {
+		entity, err := source.FetchPrivate(ctx, keyID)
 		if err != nil {
-			return nil, errors.Wrap(err, "failed to decrypt private key")
+			lastErr = err
+			continue
 		}
-		for _, subkey := range privateKeyEntity.Subkeys {
-			err = subkey.PrivateKey.Decrypt(passphraseBytes)
-			if err != nil {
-				return nil, errors.Wrap(err, "failed to decrypt private key")
-			}
+		if err := decryptPrivateKey(entity, p.Passphrase); err != nil {
+			return nil, err
 		}
+		p.cacheMu.Lock()
+		p.privateKeyCache[keyID] = entity
+		p.cacheMu.Unlock()
+		return entity, nil
 	}
+	return nil, errors.Wrap(lastErr, "no key source could provide the private key")
+}
 
-	entityList := openpgp.EntityList{privateKeyEntity}
-	md, err := openpgp.ReadMessage(bytes.NewBuffer(ciphertext), entityList, nil, nil)
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to decrypt")
-	}
-	decrypted, err := ioutil.ReadAll(md.UnverifiedBody)
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to read plaintext")
+// decryptPrivateKey decrypts an entity's private key and its subkeys in
+// place using passphrase. It is a no-op if the private key is not encrypted.
+func decryptPrivateKey(entity *openpgp.Entity, passphrase string) error {
+	if !entity.PrivateKey.Encrypted {
+		return nil
 	}
 
-	return decrypted, nil
+	passphraseBytes := []byte(passphrase)
+	if err := entity.PrivateKey.Decrypt(passphraseBytes); err != nil {
+		return errors.Wrap(err, "failed to decrypt private key")
+	}
+	for _, subkey := range entity.Subkeys {
+		if err := subkey.PrivateKey.Decrypt(passphraseBytes); err != nil {
+			return errors.Wrap(err, "failed to decrypt private key")
+		}
+	}
+	return nil
 }
 
 func readEntity(file string) (*openpgp.Entity, error) {
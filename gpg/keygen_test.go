@@ -0,0 +1,42 @@
+package gpg
+
+import (
+	"bytes"
+	"crypto"
+	"path/filepath"
+	"testing"
+)
+
+func TestGenerateKeyExportImport(t *testing.T) {
+	p, err := New(nil, "", "", "")
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	p.KeyringPath = filepath.Join(t.TempDir(), "keyring.gpg")
+
+	pair, err := p.GenerateKey("Test User", "test@example.com", "", &KeyConfig{Bits: 1024, Hash: crypto.SHA256})
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	if pair.Entity == nil {
+		t.Fatal("GenerateKey returned a nil entity")
+	}
+
+	imported, err := p.ImportKey(bytes.NewReader(pair.ArmoredPublic))
+	if err != nil {
+		t.Fatalf("ImportKey: %v", err)
+	}
+	if imported.PrimaryKey.KeyId != pair.Entity.PrimaryKey.KeyId {
+		t.Fatalf("ImportKey returned key ID %x, want %x", imported.PrimaryKey.KeyId, pair.Entity.PrimaryKey.KeyId)
+	}
+
+	keys, err := p.ListKeys()
+	if err != nil {
+		t.Fatalf("ListKeys: %v", err)
+	}
+	// GenerateKey and ImportKey each append to the keyring, so both the
+	// generated and the re-imported entity should be present.
+	if len(keys) != 2 {
+		t.Fatalf("ListKeys returned %d keys, want 2", len(keys))
+	}
+}
@@ -0,0 +1,239 @@
+package gpg
+
+import (
+	"context"
+	"crypto/sha1"
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/openpgp"
+)
+
+// KeyID identifies an OpenPGP key, as the 64-bit "long" key ID used by HKP
+// keyservers.
+type KeyID uint64
+
+// String renders id the way GnuPG and keyservers do: 16 uppercase hex digits.
+func (id KeyID) String() string {
+	return fmt.Sprintf("%016X", uint64(id))
+}
+
+// ParseKeyID parses a hex key ID, with or without a leading "0x".
+func ParseKeyID(s string) (KeyID, error) {
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "0x"), "0X")
+	v, err := strconv.ParseUint(s, 16, 64)
+	if err != nil {
+		return 0, errors.Wrapf(err, "invalid key ID %q", s)
+	}
+	return KeyID(v), nil
+}
+
+// KeyserverURL is a parsed keyserver address, as configured on GPG.KeyServer
+// / KeyserverSource.KeyServers. The scheme selects which Keyserver implementation
+// NewClient returns:
+//
+//	hkp://keys.example.org          HKP over plain HTTP
+//	hkps://keys.example.org         HKP over TLS (verifying the host cert)
+//	vks+https://keys.example.org    Hagrid-style Verifying Keyserver
+//	wkd://user@example.org          Web Key Directory lookup for user@example.org
+type KeyserverURL struct {
+	Scheme string
+	Host   string
+	Email  string
+}
+
+// ParseKeyserver parses raw into a KeyserverURL.
+func ParseKeyserver(raw string) (*KeyserverURL, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid keyserver URL %q", raw)
+	}
+
+	switch u.Scheme {
+	case "hkp", "hkps":
+		return &KeyserverURL{Scheme: u.Scheme, Host: u.Host}, nil
+	case "vks", "vks+https":
+		return &KeyserverURL{Scheme: u.Scheme, Host: u.Host}, nil
+	case "wkd":
+		if u.User == nil || len(u.Host) == 0 {
+			return nil, errors.Errorf("wkd keyserver URL must be wkd://user@domain, got %q", raw)
+		}
+		return &KeyserverURL{Scheme: u.Scheme, Host: u.Host, Email: u.User.Username() + "@" + u.Host}, nil
+	default:
+		return nil, errors.Errorf("unsupported keyserver scheme %q", u.Scheme)
+	}
+}
+
+// Keyserver looks up OpenPGP public keys by key ID or email address.
+type Keyserver interface {
+	GetKeysByID(ctx context.Context, keyID KeyID) (openpgp.EntityList, error)
+	GetKeysByEmail(ctx context.Context, email string) (openpgp.EntityList, error)
+}
+
+// NewClient returns the Keyserver implementation for ks.Scheme.
+func NewClient(ks *KeyserverURL, tlsConfig *tls.Config) (Keyserver, error) {
+	switch ks.Scheme {
+	case "hkp":
+		return &hkpClient{baseURL: "http://" + ks.Host}, nil
+	case "hkps":
+		return &hkpClient{baseURL: "https://" + ks.Host, httpClient: httpClientWithTLS(tlsConfig)}, nil
+	case "vks", "vks+https":
+		return &vksClient{baseURL: "https://" + ks.Host}, nil
+	case "wkd":
+		return &wkdClient{}, nil
+	default:
+		return nil, errors.Errorf("unsupported keyserver scheme %q", ks.Scheme)
+	}
+}
+
+func httpClientWithTLS(tlsConfig *tls.Config) *http.Client {
+	if tlsConfig == nil {
+		return http.DefaultClient
+	}
+	return &http.Client{Transport: &http.Transport{TLSClientConfig: tlsConfig}}
+}
+
+// hkpClient implements the HTTP Keyserver Protocol (RFC draft), used by
+// SKS/Hockeypuck keyservers. HKPS is the same protocol over TLS.
+type hkpClient struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+func (c *hkpClient) client() *http.Client {
+	if c.httpClient != nil {
+		return c.httpClient
+	}
+	return http.DefaultClient
+}
+
+func (c *hkpClient) GetKeysByID(ctx context.Context, keyID KeyID) (openpgp.EntityList, error) {
+	return c.lookup(ctx, "0x"+keyID.String())
+}
+
+func (c *hkpClient) GetKeysByEmail(ctx context.Context, email string) (openpgp.EntityList, error) {
+	return c.lookup(ctx, email)
+}
+
+func (c *hkpClient) lookup(ctx context.Context, search string) (openpgp.EntityList, error) {
+	reqURL := fmt.Sprintf("%s/pks/lookup?op=get&options=mr&search=%s", c.baseURL, url.QueryEscape(search))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.client().Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to reach keyserver")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("keyserver returned %s", resp.Status)
+	}
+	return openpgp.ReadArmoredKeyRing(resp.Body)
+}
+
+// vksClient implements the Hagrid Verifying Keyserver HTTP API, as used by
+// keys.openpgp.org.
+type vksClient struct {
+	baseURL string
+}
+
+func (c *vksClient) GetKeysByID(ctx context.Context, keyID KeyID) (openpgp.EntityList, error) {
+	return c.get(ctx, fmt.Sprintf("%s/vks/v1/by-fingerprint/%s", c.baseURL, keyID.String()))
+}
+
+func (c *vksClient) GetKeysByEmail(ctx context.Context, email string) (openpgp.EntityList, error) {
+	return c.get(ctx, fmt.Sprintf("%s/vks/v1/by-email/%s", c.baseURL, url.PathEscape(email)))
+}
+
+func (c *vksClient) get(ctx context.Context, reqURL string) (openpgp.EntityList, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to reach keyserver")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("keyserver returned %s", resp.Status)
+	}
+	return openpgp.ReadArmoredKeyRing(resp.Body)
+}
+
+// wkdClient implements Web Key Directory lookups. Keys are addressed by
+// email rather than key ID, so GetKeysByID is unsupported.
+type wkdClient struct{}
+
+func (c *wkdClient) GetKeysByID(ctx context.Context, keyID KeyID) (openpgp.EntityList, error) {
+	return nil, errors.New("WKD does not support lookup by key ID")
+}
+
+func (c *wkdClient) GetKeysByEmail(ctx context.Context, email string) (openpgp.EntityList, error) {
+	local, domain, err := splitEmail(email)
+	if err != nil {
+		return nil, err
+	}
+	hash := zbase32EncodeSHA1(local)
+
+	advanced := fmt.Sprintf("https://openpgpkey.%s/.well-known/openpgpkey/%s/hu/%s?l=%s", domain, domain, hash, url.QueryEscape(local))
+	if entities, err := c.fetch(ctx, advanced); err == nil {
+		return entities, nil
+	}
+
+	direct := fmt.Sprintf("https://%s/.well-known/openpgpkey/hu/%s?l=%s", domain, hash, url.QueryEscape(local))
+	return c.fetch(ctx, direct)
+}
+
+func (c *wkdClient) fetch(ctx context.Context, reqURL string) (openpgp.EntityList, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to reach WKD server")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("WKD server returned %s", resp.Status)
+	}
+
+	// WKD serves a binary transferable public key, not armored.
+	return openpgp.ReadKeyRing(resp.Body)
+}
+
+func splitEmail(email string) (local, domain string, err error) {
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return "", "", errors.Errorf("invalid email address %q", email)
+	}
+	return email[:at], email[at+1:], nil
+}
+
+func zbase32EncodeSHA1(s string) string {
+	const alphabet = "ybndrfg8ejkmcpqxot1uwisza345h769"
+	sum := sha1.Sum([]byte(strings.ToLower(s)))
+
+	var out strings.Builder
+	var buf uint64
+	var bits uint
+	for _, b := range sum {
+		buf = buf<<8 | uint64(b)
+		bits += 8
+		for bits >= 5 {
+			bits -= 5
+			out.WriteByte(alphabet[(buf>>bits)&0x1f])
+		}
+	}
+	if bits > 0 {
+		out.WriteByte(alphabet[(buf<<(5-bits))&0x1f])
+	}
+	return out.String()
+}
@@ -0,0 +1,111 @@
+package gpg
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestZbase32EncodeSHA1(t *testing.T) {
+	cases := map[string]string{
+		"test-wkd": "4hg7tescnttreaouu4z1izeuuyibwww1",
+		"Joe.Doe":  "iy9q119eutrkn8s1mk4r39qejnbu3n5q",
+	}
+	for local, want := range cases {
+		if got := zbase32EncodeSHA1(local); got != want {
+			t.Errorf("zbase32EncodeSHA1(%q) = %q, want %q", local, got, want)
+		}
+	}
+}
+
+func TestSplitEmail(t *testing.T) {
+	local, domain, err := splitEmail("test@example.org")
+	if err != nil {
+		t.Fatalf("splitEmail: %v", err)
+	}
+	if local != "test" || domain != "example.org" {
+		t.Fatalf("splitEmail returned (%q, %q), want (%q, %q)", local, domain, "test", "example.org")
+	}
+
+	if _, _, err := splitEmail("not-an-email"); err == nil {
+		t.Fatal("splitEmail returned no error for an address with no @")
+	}
+}
+
+func TestParseKeyID(t *testing.T) {
+	id, err := ParseKeyID("0xDEADBEEFDEADBEEF")
+	if err != nil {
+		t.Fatalf("ParseKeyID: %v", err)
+	}
+	if id.String() != "DEADBEEFDEADBEEF" {
+		t.Fatalf("ParseKeyID round-trip = %q, want %q", id.String(), "DEADBEEFDEADBEEF")
+	}
+}
+
+func TestParseKeyserver(t *testing.T) {
+	ks, err := ParseKeyserver("hkps://keys.example.org")
+	if err != nil {
+		t.Fatalf("ParseKeyserver: %v", err)
+	}
+	if ks.Scheme != "hkps" || ks.Host != "keys.example.org" {
+		t.Fatalf("ParseKeyserver = %+v, want scheme hkps, host keys.example.org", ks)
+	}
+
+	ks, err = ParseKeyserver("wkd://alice@example.org")
+	if err != nil {
+		t.Fatalf("ParseKeyserver: %v", err)
+	}
+	if ks.Email != "alice@example.org" {
+		t.Fatalf("ParseKeyserver wkd email = %q, want %q", ks.Email, "alice@example.org")
+	}
+
+	if _, err := ParseKeyserver("wkd://example.org"); err == nil {
+		t.Fatal("ParseKeyserver accepted a wkd URL with no user")
+	}
+}
+
+func TestHKPClientGetKeysByID(t *testing.T) {
+	pair := generateTestKeyPair(t, "Test User", "test@example.com")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("op"); got != "get" {
+			t.Errorf("request op = %q, want %q", got, "get")
+		}
+		w.Write(pair.ArmoredPublic)
+	}))
+	defer server.Close()
+
+	client := &hkpClient{baseURL: server.URL}
+	entities, err := client.GetKeysByID(context.Background(), KeyID(pair.Entity.PrimaryKey.KeyId))
+	if err != nil {
+		t.Fatalf("GetKeysByID: %v", err)
+	}
+	if len(entities) != 1 {
+		t.Fatalf("GetKeysByID returned %d entities, want 1", len(entities))
+	}
+	if entities[0].PrimaryKey.KeyId != pair.Entity.PrimaryKey.KeyId {
+		t.Fatalf("GetKeysByID returned key ID %x, want %x", entities[0].PrimaryKey.KeyId, pair.Entity.PrimaryKey.KeyId)
+	}
+}
+
+func TestVKSClientGetKeysByEmail(t *testing.T) {
+	pair := generateTestKeyPair(t, "Test User", "test@example.com")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/vks/v1/by-email/test@example.com" {
+			t.Errorf("request path = %q", r.URL.Path)
+		}
+		w.Write(pair.ArmoredPublic)
+	}))
+	defer server.Close()
+
+	client := &vksClient{baseURL: server.URL}
+	entities, err := client.GetKeysByEmail(context.Background(), "test@example.com")
+	if err != nil {
+		t.Fatalf("GetKeysByEmail: %v", err)
+	}
+	if len(entities) != 1 {
+		t.Fatalf("GetKeysByEmail returned %d entities, want 1", len(entities))
+	}
+}
@@ -0,0 +1,255 @@
+package gpg
+
+import (
+	"bytes"
+	"context"
+	"strings"
+
+	"github.com/pkg/errors"
+	"go4.org/wkfs"
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/armor"
+	"golang.org/x/crypto/openpgp/packet"
+)
+
+// KeySource locates OpenPGP entities by key ID. Sources that hold a single
+// entity (e.g. a key file) return it for an empty keyID and an error for a
+// keyID it does not match.
+type KeySource interface {
+	FetchPublic(ctx context.Context, keyID string) (*openpgp.Entity, error)
+	FetchPrivate(ctx context.Context, keyID string) (*openpgp.Entity, error)
+}
+
+// FileKeySource reads public and private keys from armored files on the
+// local filesystem. This is the original GPG behavior.
+type FileKeySource struct {
+	PublicKeyPath  string
+	PrivateKeyPath string
+}
+
+// FetchPublic implements KeySource.
+func (s *FileKeySource) FetchPublic(ctx context.Context, keyID string) (*openpgp.Entity, error) {
+	if len(s.PublicKeyPath) == 0 {
+		return nil, errors.New("no public key path configured")
+	}
+	entity, err := readEntity(s.PublicKeyPath)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkKeyIDMatch(entity, keyID); err != nil {
+		return nil, err
+	}
+	return entity, nil
+}
+
+// FetchPrivate implements KeySource.
+func (s *FileKeySource) FetchPrivate(ctx context.Context, keyID string) (*openpgp.Entity, error) {
+	if len(s.PrivateKeyPath) == 0 {
+		return nil, errors.New("no private key path configured")
+	}
+	entity, err := readEntity(s.PrivateKeyPath)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkKeyIDMatch(entity, keyID); err != nil {
+		return nil, err
+	}
+	return entity, nil
+}
+
+// MemoryKeySource serves keys from armored bytes supplied at construction,
+// useful for tests and for callers that fetch key material from a secret
+// store rather than a file.
+type MemoryKeySource struct {
+	Public  []byte
+	Private []byte
+}
+
+// FetchPublic implements KeySource.
+func (s *MemoryKeySource) FetchPublic(ctx context.Context, keyID string) (*openpgp.Entity, error) {
+	if len(s.Public) == 0 {
+		return nil, errors.New("no public key bytes configured")
+	}
+	entity, err := readEntityBytes(s.Public)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkKeyIDMatch(entity, keyID); err != nil {
+		return nil, err
+	}
+	return entity, nil
+}
+
+// FetchPrivate implements KeySource.
+func (s *MemoryKeySource) FetchPrivate(ctx context.Context, keyID string) (*openpgp.Entity, error) {
+	if len(s.Private) == 0 {
+		return nil, errors.New("no private key bytes configured")
+	}
+	entity, err := readEntityBytes(s.Private)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkKeyIDMatch(entity, keyID); err != nil {
+		return nil, err
+	}
+	return entity, nil
+}
+
+// KeyserverSource fetches public keys from one or more configured
+// keyservers, chosen by URL scheme (hkp://, hkps://, vks+https://,
+// wkd://user@domain). Servers are tried in order, falling back to the next
+// one on failure. It cannot provide private keys.
+type KeyserverSource struct {
+	// KeyServer is the primary keyserver URL, tried first.
+	KeyServer string
+	// KeyServers are additional keyserver URLs, tried in order if KeyServer
+	// is unset or fails.
+	KeyServers []string
+}
+
+func (s *KeyserverSource) servers() []string {
+	if len(s.KeyServer) == 0 {
+		return s.KeyServers
+	}
+	return append([]string{s.KeyServer}, s.KeyServers...)
+}
+
+// FetchPublic implements KeySource. keyID may be a hex key ID or, for
+// keyservers that key by address (VKS, WKD), an email address.
+func (s *KeyserverSource) FetchPublic(ctx context.Context, keyID string) (*openpgp.Entity, error) {
+	servers := s.servers()
+	if len(servers) == 0 {
+		return nil, errors.New("no keyserver configured")
+	}
+
+	var lastErr error
+	for _, raw := range servers {
+		entity, err := s.fetchFrom(ctx, raw, keyID)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		return entity, nil
+	}
+	return nil, errors.Wrap(lastErr, "failed to fetch key from any configured keyserver")
+}
+
+func (s *KeyserverSource) fetchFrom(ctx context.Context, raw, keyID string) (*openpgp.Entity, error) {
+	keyServer, err := ParseKeyserver(raw)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse keyserver")
+	}
+	client, err := NewClient(keyServer, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create keyserver client")
+	}
+
+	var entities openpgp.EntityList
+	if keyServer.Scheme == "wkd" {
+		entities, err = client.GetKeysByEmail(ctx, keyServer.Email)
+	} else if strings.Contains(keyID, "@") {
+		entities, err = client.GetKeysByEmail(ctx, keyID)
+	} else {
+		var parsedKeyID KeyID
+		parsedKeyID, err = ParseKeyID(keyID)
+		if err == nil {
+			entities, err = client.GetKeysByID(ctx, parsedKeyID)
+		}
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get key")
+	}
+	if len(entities) == 0 {
+		return nil, errors.New("no matching key found")
+	}
+	return entities[0], nil
+}
+
+// FetchPrivate implements KeySource. Keyservers never hold private keys.
+func (s *KeyserverSource) FetchPrivate(ctx context.Context, keyID string) (*openpgp.Entity, error) {
+	return nil, errors.New("keyserver source does not support private keys")
+}
+
+// WKFSKeySource reads public and private keys through go4.org/wkfs, so keys
+// can live on a "well-known filesystem" such as GCS (/gcs/bucket/object) in
+// addition to the local disk, without the caller needing to branch on the
+// deployment environment.
+type WKFSKeySource struct {
+	PublicKeyPath  string
+	PrivateKeyPath string
+}
+
+// FetchPublic implements KeySource.
+func (s *WKFSKeySource) FetchPublic(ctx context.Context, keyID string) (*openpgp.Entity, error) {
+	if len(s.PublicKeyPath) == 0 {
+		return nil, errors.New("no public key path configured")
+	}
+	entity, err := readEntityWKFS(s.PublicKeyPath)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkKeyIDMatch(entity, keyID); err != nil {
+		return nil, err
+	}
+	return entity, nil
+}
+
+// FetchPrivate implements KeySource.
+func (s *WKFSKeySource) FetchPrivate(ctx context.Context, keyID string) (*openpgp.Entity, error) {
+	if len(s.PrivateKeyPath) == 0 {
+		return nil, errors.New("no private key path configured")
+	}
+	entity, err := readEntityWKFS(s.PrivateKeyPath)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkKeyIDMatch(entity, keyID); err != nil {
+		return nil, err
+	}
+	return entity, nil
+}
+
+func readEntityWKFS(file string) (*openpgp.Entity, error) {
+	f, err := wkfs.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	block, err := armor.Decode(f)
+	if err != nil {
+		return nil, err
+	}
+	return openpgp.ReadEntity(packet.NewReader(block.Body))
+}
+
+// checkKeyIDMatch reports an error if entity's key ID doesn't match keyID.
+// An empty keyID matches any entity, since single-key sources are commonly
+// configured without one. Single-entity sources like FileKeySource and
+// MemoryKeySource hold exactly one key regardless of which ID is requested,
+// so without this check a caller asking for one recipient's key could
+// silently be handed a different recipient's key instead.
+func checkKeyIDMatch(entity *openpgp.Entity, keyID string) error {
+	if len(keyID) == 0 || entity.PrimaryKey == nil {
+		return nil
+	}
+	if entity.PrimaryKey.KeyIdString() == keyID || entity.PrimaryKey.KeyIdShortString() == keyID {
+		return nil
+	}
+	for _, subkey := range entity.Subkeys {
+		if subkey.PublicKey == nil {
+			continue
+		}
+		if subkey.PublicKey.KeyIdString() == keyID || subkey.PublicKey.KeyIdShortString() == keyID {
+			return nil
+		}
+	}
+	return errors.Errorf("key source does not have a key matching ID %q", keyID)
+}
+
+func readEntityBytes(armored []byte) (*openpgp.Entity, error) {
+	block, err := armor.Decode(bytes.NewReader(armored))
+	if err != nil {
+		return nil, err
+	}
+	return openpgp.ReadEntity(packet.NewReader(block.Body))
+}
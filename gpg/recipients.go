@@ -0,0 +1,106 @@
+package gpg
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/packet"
+)
+
+// recipients resolves every configured recipient (the single-key
+// PublicKeyPath/KeyID aliases plus PublicKeyPaths/KeyIDs) into a
+// deduplicated entity list suitable for openpgp.Encrypt.
+func (p *GPG) recipients(ctx context.Context) (openpgp.EntityList, error) {
+	var entities openpgp.EntityList
+	seen := make(map[[20]byte]bool)
+	add := func(entity *openpgp.Entity) {
+		if entity.PrimaryKey == nil || seen[entity.PrimaryKey.Fingerprint] {
+			return
+		}
+		seen[entity.PrimaryKey.Fingerprint] = true
+		entities = append(entities, entity)
+	}
+
+	if len(p.PublicKeyPath) > 0 || len(p.KeyID) > 0 {
+		entity, err := p.loadPublicEntity(ctx, p.KeyID)
+		if err != nil {
+			return nil, err
+		}
+		add(entity)
+	}
+	for _, path := range p.PublicKeyPaths {
+		entity, err := readEntity(path)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read public key %q", path)
+		}
+		add(entity)
+	}
+	for _, keyID := range p.KeyIDs {
+		entity, err := p.loadPublicEntity(ctx, keyID)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to load public key %q", keyID)
+		}
+		add(entity)
+	}
+
+	if len(entities) == 0 {
+		return nil, errors.New("no recipients configured")
+	}
+	return entities, nil
+}
+
+// isHiddenRecipient reports whether entity matches one of the configured
+// HiddenRecipients, by key ID or by identity email address.
+func (p *GPG) isHiddenRecipient(entity *openpgp.Entity) bool {
+	for _, hidden := range p.HiddenRecipients {
+		if entity.PrimaryKey != nil && entity.PrimaryKey.KeyIdString() == hidden {
+			return true
+		}
+		for _, identity := range entity.Identities {
+			if identity.UserId != nil && identity.UserId.Email == hidden {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// hideRecipientKeyIDs zeroes the key ID of every entity matching
+// p.HiddenRecipients, per RFC 4880 §5.1's wildcard recipient, and returns a
+// function that restores the original key IDs once encryption is done.
+func (p *GPG) hideRecipientKeyIDs(entities openpgp.EntityList) func() {
+	if len(p.HiddenRecipients) == 0 {
+		return func() {}
+	}
+
+	type restoreEntry struct {
+		key      *packet.PublicKey
+		original uint64
+	}
+	var restore []restoreEntry
+	for _, entity := range entities {
+		if !p.isHiddenRecipient(entity) {
+			continue
+		}
+		keys := append([]*packet.PublicKey{entity.PrimaryKey}, subkeyPublicKeys(entity)...)
+		for _, key := range keys {
+			restore = append(restore, restoreEntry{key: key, original: key.KeyId})
+			key.KeyId = 0
+		}
+	}
+
+	return func() {
+		for _, entry := range restore {
+			entry.key.KeyId = entry.original
+		}
+	}
+}
+
+func subkeyPublicKeys(entity *openpgp.Entity) []*packet.PublicKey {
+	keys := make([]*packet.PublicKey, 0, len(entity.Subkeys))
+	for _, subkey := range entity.Subkeys {
+		keys = append(keys, subkey.PublicKey)
+	}
+	return keys
+}
@@ -0,0 +1,71 @@
+package gpg
+
+import (
+	"bytes"
+	"crypto"
+	"testing"
+)
+
+func generateTestKeyPair(t *testing.T, name, email string) *KeyPair {
+	t.Helper()
+
+	keygen, err := New(nil, "", "", "")
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	pair, err := keygen.GenerateKey(name, email, "", &KeyConfig{Bits: 1024, Hash: crypto.SHA256})
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	return pair
+}
+
+func TestMultiRecipientEncryptDecrypt(t *testing.T) {
+	alice := generateTestKeyPair(t, "Alice", "alice@example.com")
+	bob := generateTestKeyPair(t, "Bob", "bob@example.com")
+
+	aliceKeyID := alice.Entity.PrimaryKey.KeyIdString()
+	bobKeyID := bob.Entity.PrimaryKey.KeyIdString()
+
+	encryptor, err := New([]KeySource{
+		&MemoryKeySource{Public: alice.ArmoredPublic},
+		&MemoryKeySource{Public: bob.ArmoredPublic},
+	}, "", "", "")
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	encryptor.KeyIDs = []string{aliceKeyID, bobKeyID}
+
+	plaintext := []byte("hello, multiple recipients")
+	ciphertext, err := encryptor.Encrypt(plaintext)
+	if err != nil {
+		t.Fatalf("Encrypt: %v", err)
+	}
+
+	for _, pair := range []*KeyPair{alice, bob} {
+		decryptor, err := New([]KeySource{
+			&MemoryKeySource{Public: pair.ArmoredPublic, Private: pair.ArmoredPrivate},
+		}, "", "", "")
+		if err != nil {
+			t.Fatalf("New: %v", err)
+		}
+
+		decrypted, err := decryptor.Decrypt(ciphertext)
+		if err != nil {
+			t.Fatalf("Decrypt: %v", err)
+		}
+		if !bytes.Equal(decrypted, plaintext) {
+			t.Fatalf("Decrypt returned %q, want %q", decrypted, plaintext)
+		}
+	}
+}
+
+func TestMemoryKeySourceRejectsMismatchedKeyID(t *testing.T) {
+	alice := generateTestKeyPair(t, "Alice", "alice@example.com")
+	bob := generateTestKeyPair(t, "Bob", "bob@example.com")
+
+	source := &MemoryKeySource{Public: alice.ArmoredPublic}
+	if _, err := source.FetchPublic(nil, bob.Entity.PrimaryKey.KeyIdString()); err == nil {
+		t.Fatal("FetchPublic returned no error for a key ID the source does not hold")
+	}
+}
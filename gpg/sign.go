@@ -0,0 +1,273 @@
+package gpg
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/armor"
+	"golang.org/x/crypto/openpgp/clearsign"
+	"golang.org/x/crypto/openpgp/packet"
+)
+
+// Signer describes the entity that produced a verified signature.
+type Signer struct {
+	Entity      *openpgp.Entity
+	Fingerprint [20]byte
+	Hash        string
+	CreatedAt   time.Time
+}
+
+// inlineMessageType is the armor type for an inline-signed message, as
+// opposed to SignatureType ("PGP SIGNATURE"), which x/crypto/openpgp
+// reserves for detached signatures.
+const inlineMessageType = "PGP MESSAGE"
+
+// Sign produces an armored, inline-signed OpenPGP message for plaintext using
+// the provider's private key. It is equivalent to `gpg --sign`.
+func (p *GPG) Sign(plaintext []byte) ([]byte, error) {
+	signer, err := p.signingEntity()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load signing key")
+	}
+
+	buf := new(bytes.Buffer)
+	armorWriter, err := armor.Encode(buf, inlineMessageType, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create armor writer")
+	}
+	writer, err := openpgp.Sign(armorWriter, signer, nil, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to sign")
+	}
+	if _, err := writer.Write(plaintext); err != nil {
+		return nil, errors.Wrap(err, "failed to write signed message")
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	if err := armorWriter.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// SignDetached produces a detached, armored signature (`.asc`) for plaintext.
+func (p *GPG) SignDetached(plaintext []byte) ([]byte, error) {
+	signer, err := p.signingEntity()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load signing key")
+	}
+
+	buf := new(bytes.Buffer)
+	if err := openpgp.ArmoredDetachSign(buf, signer, bytes.NewReader(plaintext), nil); err != nil {
+		return nil, errors.Wrap(err, "failed to create detached signature")
+	}
+
+	return buf.Bytes(), nil
+}
+
+// SignClearsign produces an RFC 4880 clearsigned message: the dash-escaped
+// plaintext wrapped in a BEGIN/END PGP SIGNED MESSAGE block followed by the
+// armored signature.
+func (p *GPG) SignClearsign(plaintext []byte) ([]byte, error) {
+	signer, err := p.signingEntity()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load signing key")
+	}
+
+	buf := new(bytes.Buffer)
+	writer, err := clearsign.Encode(buf, signer.PrivateKey, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create clearsign writer")
+	}
+	if _, err := writer.Write(plaintext); err != nil {
+		return nil, errors.Wrap(err, "failed to write clearsigned message")
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Verify checks a signature produced by Sign, SignDetached or SignClearsign
+// against plaintext and returns the signer entity.
+//
+// For inline and clearsigned messages, plaintext must be nil and signedOrSig
+// holds the whole message; for detached signatures, signedOrSig is the
+// signature itself and plaintext is the data it was computed over. Any
+// non-nil plaintext makes Verify treat signedOrSig as a detached signature.
+func (p *GPG) Verify(signedOrSig, plaintext []byte) (*Signer, error) {
+	keyring, err := p.verificationKeyring()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load verification keys")
+	}
+
+	if plaintext != nil {
+		return verifyDetached(keyring, signedOrSig, plaintext)
+	}
+
+	if block, _ := clearsign.Decode(signedOrSig); block != nil {
+		return verifySignature(keyring, block.ArmoredSignature.Body, bytes.NewReader(block.Bytes))
+	}
+
+	armorBlock, err := armor.Decode(bytes.NewReader(signedOrSig))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decode armored message")
+	}
+	md, err := openpgp.ReadMessage(armorBlock.Body, keyring, nil, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read signed message")
+	}
+	if _, err := ioutil.ReadAll(md.UnverifiedBody); err != nil {
+		return nil, errors.Wrap(err, "failed to read signed body")
+	}
+	return signerFromMessageDetails(md)
+}
+
+func verifyDetached(keyring openpgp.EntityList, sig, plaintext []byte) (*Signer, error) {
+	entity, err := openpgp.CheckArmoredDetachedSignature(keyring, bytes.NewReader(plaintext), bytes.NewReader(sig))
+	if err != nil {
+		return nil, classifySignatureError(err)
+	}
+	sigPacket, err := readSignaturePacket(sig)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkSignerValidity(entity); err != nil {
+		return nil, err
+	}
+	return newSigner(entity, sigPacket), nil
+}
+
+// verifySignature checks a signature already extracted from a clearsign
+// block. Unlike the standalone detached signatures handled by
+// verifyDetached, sig is already the output of clearsign.Decode's own
+// armor.Decode, so it must not be armor-decoded a second time.
+func verifySignature(keyring openpgp.EntityList, sig io.Reader, body io.Reader) (*Signer, error) {
+	sigBytes, err := ioutil.ReadAll(sig)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read signature")
+	}
+
+	entity, err := openpgp.CheckDetachedSignature(keyring, body, bytes.NewReader(sigBytes))
+	if err != nil {
+		return nil, classifySignatureError(err)
+	}
+	sigPacket, err := parseSignaturePacket(bytes.NewReader(sigBytes))
+	if err != nil {
+		return nil, err
+	}
+	if err := checkSignerValidity(entity); err != nil {
+		return nil, err
+	}
+	return newSigner(entity, sigPacket), nil
+}
+
+func signerFromMessageDetails(md *openpgp.MessageDetails) (*Signer, error) {
+	if md.SignatureError != nil {
+		return nil, classifySignatureError(md.SignatureError)
+	}
+	if md.SignedBy == nil {
+		return nil, errors.New("message is not signed")
+	}
+	if err := checkSignerValidity(md.SignedBy.Entity); err != nil {
+		return nil, err
+	}
+	return newSigner(md.SignedBy.Entity, md.Signature), nil
+}
+
+func newSigner(entity *openpgp.Entity, sig *packet.Signature) *Signer {
+	s := &Signer{Entity: entity}
+	if entity.PrimaryKey != nil {
+		s.Fingerprint = entity.PrimaryKey.Fingerprint
+	}
+	if sig != nil {
+		s.Hash = sig.Hash.String()
+		s.CreatedAt = sig.CreationTime
+	}
+	return s
+}
+
+// readSignaturePacket extracts the first signature packet out of an armored
+// detached signature, used to surface its hash algorithm and creation time.
+func readSignaturePacket(armoredSig []byte) (*packet.Signature, error) {
+	block, err := armor.Decode(bytes.NewReader(armoredSig))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decode armored signature")
+	}
+	return parseSignaturePacket(block.Body)
+}
+
+// parseSignaturePacket extracts the first signature packet out of r, which
+// must already be raw (non-armored) packet data.
+func parseSignaturePacket(r io.Reader) (*packet.Signature, error) {
+	reader := packet.NewReader(r)
+	for {
+		p, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to parse signature")
+		}
+		if sig, ok := p.(*packet.Signature); ok {
+			return sig, nil
+		}
+	}
+	return nil, errors.New("no signature packet found")
+}
+
+// Sentinel errors returned by checkSignerValidity. x/crypto/openpgp exposes
+// no expired-key or expired-signature sentinel of its own, and its
+// errors.ErrKeyRevoked is never actually returned by any of its verification
+// paths, so revocation and expiry are checked manually against the entity.
+var (
+	ErrSignerRevoked = errors.New("signer key has been revoked")
+	ErrSignerExpired = errors.New("signer key has expired")
+)
+
+// checkSignerValidity reports whether entity's key has been revoked or has
+// expired, after cryptographic signature verification has already succeeded.
+func checkSignerValidity(entity *openpgp.Entity) error {
+	if len(entity.Revocations) > 0 {
+		return ErrSignerRevoked
+	}
+
+	now := time.Now()
+	for _, identity := range entity.Identities {
+		if identity.SelfSignature == nil {
+			continue
+		}
+		if identity.SelfSignature.RevocationReason != nil {
+			return ErrSignerRevoked
+		}
+		if identity.SelfSignature.KeyExpired(now) {
+			return ErrSignerExpired
+		}
+	}
+	return nil
+}
+
+// classifySignatureError wraps a cryptographic verification failure.
+func classifySignatureError(err error) error {
+	return errors.Wrap(err, "signature verification failed")
+}
+
+func (p *GPG) signingEntity() (*openpgp.Entity, error) {
+	return p.loadPrivateEntity(context.TODO(), p.KeyID)
+}
+
+func (p *GPG) verificationKeyring() (openpgp.EntityList, error) {
+	entity, err := p.loadPublicEntity(context.TODO(), p.KeyID)
+	if err != nil {
+		return nil, err
+	}
+	return openpgp.EntityList{entity}, nil
+}
@@ -0,0 +1,109 @@
+package gpg
+
+import (
+	"bytes"
+	"crypto"
+	"testing"
+
+	// Registers RIPEMD160, the hash openpgp.Encrypt falls back to for a
+	// recipient key whose self-signature lists no preferred hash.
+	_ "golang.org/x/crypto/ripemd160"
+)
+
+func testGPG(t *testing.T) *GPG {
+	t.Helper()
+
+	keygen, err := New(nil, "", "", "")
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	pair, err := keygen.GenerateKey("Test User", "test@example.com", "", &KeyConfig{Bits: 1024, Hash: crypto.SHA256})
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+
+	source := &MemoryKeySource{Public: pair.ArmoredPublic, Private: pair.ArmoredPrivate}
+	p, err := New([]KeySource{source}, "", "", "")
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	p.PrivateKeyPath = "memory"
+	p.PublicKeyPath = "memory"
+	return p
+}
+
+func TestSignVerifyInline(t *testing.T) {
+	p := testGPG(t)
+	plaintext := []byte("hello, inline signature")
+
+	signed, err := p.Sign(plaintext)
+	if err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+
+	signer, err := p.Verify(signed, nil)
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if signer.Entity == nil {
+		t.Fatal("Verify returned a nil signer entity")
+	}
+}
+
+func TestSignVerifyDetached(t *testing.T) {
+	p := testGPG(t)
+	plaintext := []byte("hello, detached signature")
+
+	sig, err := p.SignDetached(plaintext)
+	if err != nil {
+		t.Fatalf("SignDetached: %v", err)
+	}
+
+	signer, err := p.Verify(sig, plaintext)
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if signer.Entity == nil {
+		t.Fatal("Verify returned a nil signer entity")
+	}
+}
+
+func TestSignVerifyClearsign(t *testing.T) {
+	p := testGPG(t)
+	plaintext := []byte("hello, clearsigned message")
+
+	signed, err := p.SignClearsign(plaintext)
+	if err != nil {
+		t.Fatalf("SignClearsign: %v", err)
+	}
+
+	signer, err := p.Verify(signed, nil)
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if signer.Entity == nil {
+		t.Fatal("Verify returned a nil signer entity")
+	}
+}
+
+func TestEncryptDecryptVerify(t *testing.T) {
+	p := testGPG(t)
+	p.Passphrase = "unused"
+	plaintext := []byte("hello, sign-then-encrypt")
+
+	ciphertext, err := p.Encrypt(plaintext)
+	if err != nil {
+		t.Fatalf("Encrypt: %v", err)
+	}
+
+	decrypted, signer, err := p.DecryptVerify(ciphertext)
+	if err != nil {
+		t.Fatalf("DecryptVerify: %v", err)
+	}
+	if !bytes.Equal(decrypted, plaintext) {
+		t.Fatalf("DecryptVerify returned %q, want %q", decrypted, plaintext)
+	}
+	if signer == nil || signer.Entity == nil {
+		t.Fatal("DecryptVerify returned a nil signer")
+	}
+}
@@ -0,0 +1,76 @@
+package gpg
+
+import (
+	"context"
+	"io"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/packet"
+)
+
+// defaultChunkSize is used to size the buffer copying plaintext into the
+// PGP writer when StreamOptions doesn't specify one.
+const defaultChunkSize = 64 * 1024
+
+// StreamOptions configures EncryptStream.
+type StreamOptions struct {
+	// Compress enables PGP compression (ZLIB) of the plaintext before
+	// encryption.
+	Compress bool
+	// ChunkSize overrides the buffer size used to copy src into the
+	// underlying PGP writer. Defaults to 64KiB.
+	ChunkSize int
+}
+
+// EncryptStream encrypts src to dst without buffering the whole plaintext or
+// ciphertext in memory, making it suitable for multi-GB payloads. The
+// ciphertext can be decrypted by any of the configured recipients; see
+// GPG.PublicKeyPaths, GPG.KeyIDs, and GPG.HiddenRecipients.
+func (p *GPG) EncryptStream(dst io.Writer, src io.Reader, opts *StreamOptions) error {
+	entities, err := p.recipients(context.TODO())
+	if err != nil {
+		return errors.Wrap(err, "failed to load recipients")
+	}
+	restoreKeyIDs := p.hideRecipientKeyIDs(entities)
+	defer restoreKeyIDs()
+
+	var pgpConfig *packet.Config
+	chunkSize := defaultChunkSize
+	if opts != nil {
+		if opts.Compress {
+			pgpConfig = &packet.Config{DefaultCompressionAlgo: packet.CompressionZLIB}
+		}
+		if opts.ChunkSize > 0 {
+			chunkSize = opts.ChunkSize
+		}
+	}
+
+	writer, err := openpgp.Encrypt(dst, entities, nil, nil, pgpConfig)
+	if err != nil {
+		return errors.Wrap(err, "failed to encrypt")
+	}
+	if _, err := io.CopyBuffer(writer, src, make([]byte, chunkSize)); err != nil {
+		return errors.Wrap(err, "failed to write ciphertext")
+	}
+	return writer.Close()
+}
+
+// DecryptStream decrypts src into dst without buffering the whole ciphertext
+// or plaintext in memory, and returns the message details so callers can
+// inspect signature verification results.
+func (p *GPG) DecryptStream(dst io.Writer, src io.Reader) (*openpgp.MessageDetails, error) {
+	entity, err := p.loadPrivateEntity(context.TODO(), p.KeyID)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load private key")
+	}
+
+	md, err := openpgp.ReadMessage(src, openpgp.EntityList{entity}, nil, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decrypt")
+	}
+	if _, err := io.Copy(dst, md.UnverifiedBody); err != nil {
+		return nil, errors.Wrap(err, "failed to read plaintext")
+	}
+	return md, nil
+}
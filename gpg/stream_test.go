@@ -0,0 +1,24 @@
+package gpg
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestEncryptDecryptStream(t *testing.T) {
+	p := testGPG(t)
+	plaintext := []byte("hello, streamed encryption")
+
+	var ciphertext bytes.Buffer
+	if err := p.EncryptStream(&ciphertext, bytes.NewReader(plaintext), &StreamOptions{Compress: true}); err != nil {
+		t.Fatalf("EncryptStream: %v", err)
+	}
+
+	var decrypted bytes.Buffer
+	if _, err := p.DecryptStream(&decrypted, &ciphertext); err != nil {
+		t.Fatalf("DecryptStream: %v", err)
+	}
+	if !bytes.Equal(decrypted.Bytes(), plaintext) {
+		t.Fatalf("DecryptStream returned %q, want %q", decrypted.Bytes(), plaintext)
+	}
+}